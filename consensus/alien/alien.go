@@ -0,0 +1,294 @@
+// Copyright 2017 The gttc Authors
+// This file is part of the gttc library.
+//
+// The gttc library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gttc library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gttc library. If not, see <http://www.gnu.org/licenses/>.
+
+package alien
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/TTCECO/gttc/common"
+	"github.com/TTCECO/gttc/consensus"
+	"github.com/TTCECO/gttc/core/state"
+	"github.com/TTCECO/gttc/core/types"
+	"github.com/TTCECO/gttc/crypto"
+	"github.com/TTCECO/gttc/ethdb"
+	"github.com/TTCECO/gttc/params"
+	"github.com/TTCECO/gttc/rlp"
+	"github.com/TTCECO/gttc/rpc"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	extraVanity = 32 // Fixed number of extra-data prefix bytes reserved for signer vanity
+	extraSeal   = 65 // Fixed number of extra-data suffix bytes reserved for signer seal
+
+	inmemorySnapshots  = 128  // Number of recent snapshots to keep in memory
+	inmemorySignatures = 4096 // Number of recent block signatures to keep in memory
+
+	checkpointInterval = 1024 // Number of blocks after which a snapshot is persisted to disk
+)
+
+// SignerFn is a callback type used to sign a header with the local signer key.
+type SignerFn func(common.Address, []byte) ([]byte, error)
+
+// Alien is the delegated-proof-of-stake consensus engine.
+type Alien struct {
+	config *params.AlienConfig // Consensus engine configuration parameters
+	db     ethdb.Database      // Database to store and retrieve snapshot checkpoints
+
+	recents    *lru.ARCCache // Snapshots for recent blocks to speed up reorgs
+	signatures *lru.ARCCache // Signatures of recent blocks to speed up mining
+
+	signer common.Address // Ethereum address of the signing key
+	signFn SignerFn       // Signer function to authorize hashes with
+	lock   sync.RWMutex   // Protects the signer fields
+}
+
+// New creates a Alien delegated-proof-of-stake consensus engine with the initial
+// signers set to the ones provided by the user.
+func New(config *params.AlienConfig, db ethdb.Database) *Alien {
+	recents, _ := lru.NewARC(inmemorySnapshots)
+	signatures, _ := lru.NewARC(inmemorySignatures)
+
+	return &Alien{
+		config:     config,
+		db:         db,
+		recents:    recents,
+		signatures: signatures,
+	}
+}
+
+// APIs implements consensus.Engine, returning the user facing RPC API to
+// inspect signers, candidate tallies and punishments.
+func (a *Alien) APIs(chain consensus.ChainReader) []rpc.API {
+	return []rpc.API{{
+		Namespace: "alien",
+		Version:   "1.0",
+		Service:   &API{chain: chain, alien: a},
+		Public:    false,
+	}}
+}
+
+// Author retrieves the Ethereum address of the account that minted the given
+// block, which may be different from the header's coinbase if a consensus
+// engine is based on signatures.
+func (a *Alien) Author(header *types.Header) (common.Address, error) {
+	return ecrecover(header, a.signatures)
+}
+
+// snapshot retrieves the authorization snapshot at a given point in time by
+// walking backwards from the requested block until a known snapshot is found
+// in the recents ARC cache or, failing that, on disk at a checkpoint, and
+// then replaying the collected headers on top of it.
+func (a *Alien) snapshot(chain consensus.ChainReader, number uint64, hash common.Hash, parents []*types.Header) (*Snapshot, error) {
+	var (
+		headers []*types.Header
+		snap    *Snapshot
+	)
+
+	for snap == nil {
+		// If an in-memory snapshot was found, use that
+		if s, ok := a.recents.Get(hash); ok {
+			snap = s.(*Snapshot)
+			break
+		}
+		// If an on-disk checkpoint snapshot can be found, use that
+		if number%checkpointInterval == 0 {
+			if s, err := loadSnapshot(a.config, a.signatures, a.db, hash); err == nil {
+				snap = s
+				break
+			}
+		}
+		var header *types.Header
+		if len(parents) > 0 {
+			header = parents[len(parents)-1]
+			if header.Hash() != hash || header.Number.Uint64() != number {
+				return nil, consensus.ErrUnknownAncestor
+			}
+			parents = parents[:len(parents)-1]
+		} else {
+			header = chain.GetHeader(hash, number)
+			if header == nil {
+				return nil, consensus.ErrUnknownAncestor
+			}
+		}
+		headers = append(headers, header)
+		number, hash = number-1, header.ParentHash
+
+		if number == 0 {
+			break
+		}
+	}
+
+	if snap == nil {
+		genesis := chain.GetHeaderByNumber(0)
+		if genesis == nil {
+			return nil, errUnknownSnapshot
+		}
+		snap = newSnapshot(a.config, a.signatures, genesis.Hash(), nil)
+	}
+
+	// Previous snapshot found, apply any pending headers on top of it
+	for i := 0; i < len(headers)/2; i++ {
+		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
+	}
+	snap, err := snap.apply(headers)
+	if err != nil {
+		return nil, err
+	}
+	a.recents.Add(snap.Hash, snap)
+
+	// If we've generated a new checkpoint snapshot, save it to disk
+	if snap.Number%checkpointInterval == 0 && len(headers) > 0 {
+		if err = snap.store(a.db); err != nil {
+			return nil, err
+		}
+	}
+	return snap, nil
+}
+
+// Finalize refreshes the parent snapshot's tally against live balances,
+// credits the block's minted reward to the sealing signer and the
+// delegators backing it using that refreshed tally, assembles the final
+// block, and caches the refreshed snapshot under the block's hash so later
+// blocks and RPCs build on live-balance stakes instead of stale ones.
+func (a *Alien) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	parent, err := a.snapshot(chain, header.Number.Uint64()-1, header.ParentHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	// parent may be the very pointer cached in a.recents; refreshTally below
+	// mutates in place, so work on a private copy for this block instead of
+	// corrupting the shared, ARC-cached snapshot of the parent block
+	snap := parent.copy()
+	snap.refreshTally(state)
+
+	subsidy := blockReward(a.config, header.Number.Uint64())
+	if subsidy.Sign() > 0 {
+		state.AddBalance(header.Coinbase, snap.rewardFor(header.Coinbase, header.Coinbase, subsidy))
+
+		paid := map[common.Address]bool{header.Coinbase: true}
+		for voter, vote := range snap.Votes {
+			if vote.Candidate != header.Coinbase || paid[voter] {
+				continue
+			}
+			state.AddBalance(voter, snap.rewardFor(header.Coinbase, voter, subsidy))
+			paid[voter] = true
+		}
+		for _, delegator := range snap.Delegations[header.Coinbase] {
+			if paid[delegator] {
+				continue
+			}
+			state.AddBalance(delegator, snap.rewardFor(header.Coinbase, delegator, subsidy))
+			paid[delegator] = true
+		}
+	}
+
+	headerExtra := HeaderExtra{}
+	if len(header.Extra) >= extraVanity+extraSeal {
+		rlp.DecodeBytes(header.Extra[extraVanity:len(header.Extra)-extraSeal], &headerExtra)
+	}
+	headerExtra.CoinbaseReward = subsidy
+	extraData, err := rlp.EncodeToBytes(headerExtra)
+	if err != nil {
+		return nil, err
+	}
+	header.Extra = append(header.Extra[:extraVanity], extraData...)
+	header.Extra = append(header.Extra, make([]byte, extraSeal)...)
+
+	header.Root = state.IntermediateRoot(true)
+	block := types.NewBlock(header, txs, uncles, receipts)
+
+	// fold the refreshed tally back into the snapshot chain under this block's
+	// final hash, so the next block's Finalize, the GetTally/GetSignerQueueAt
+	// RPCs and getSignerQueue all see live-balance stakes instead of this
+	// being recomputed and thrown away every block
+	snap.Number = header.Number.Uint64()
+	snap.Hash = block.Hash()
+	a.recents.Add(snap.Hash, snap)
+
+	return block, nil
+}
+
+// blockReward computes the subsidy for the given block number, halving
+// every config.RewardHalvingPeriod blocks (0 means no halving ever occurs).
+func blockReward(config *params.AlienConfig, number uint64) *big.Int {
+	if config.BlockReward == nil {
+		return big.NewInt(0)
+	}
+	if config.RewardHalvingPeriod == 0 {
+		return new(big.Int).Set(config.BlockReward)
+	}
+	halvings := number / config.RewardHalvingPeriod
+	if halvings >= 64 {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Rsh(config.BlockReward, uint(halvings))
+}
+
+// ecrecover extracts the Ethereum account address from a signed header.
+func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, error) {
+	// Have the sig cache say no comment if it is not available
+	hash := header.Hash()
+	if sigcache != nil {
+		if address, known := sigcache.Get(hash); known {
+			return address.(common.Address), nil
+		}
+	}
+	// Retrieve the signature from the header extra-data
+	if len(header.Extra) < extraSeal {
+		return common.Address{}, errUnknownBlock
+	}
+	signature := header.Extra[len(header.Extra)-extraSeal:]
+
+	// Recover the public key and the Ethereum address
+	pubkey, err := crypto.SigToPub(sigHash(header).Bytes(), signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	signer := crypto.PubkeyToAddress(*pubkey)
+
+	if sigcache != nil {
+		sigcache.Add(hash, signer)
+	}
+	return signer, nil
+}
+
+// sigHash returns the hash which is used as input for the signer proof that
+// verifies the header authorship. It is computed over every header field
+// except the trailing extraSeal signature bytes in Extra, so the seal itself
+// is never part of the message it signs over.
+func sigHash(header *types.Header) common.Hash {
+	b, _ := rlp.EncodeToBytes([]interface{}{
+		header.ParentHash,
+		header.UncleHash,
+		header.Coinbase,
+		header.Root,
+		header.TxHash,
+		header.ReceiptHash,
+		header.Bloom,
+		header.Difficulty,
+		header.Number,
+		header.GasLimit,
+		header.GasUsed,
+		header.Time,
+		header.Extra[:len(header.Extra)-extraSeal],
+		header.MixDigest,
+		header.Nonce,
+	})
+	return crypto.Keccak256Hash(b)
+}
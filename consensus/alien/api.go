@@ -0,0 +1,120 @@
+// Copyright 2017 The gttc Authors
+// This file is part of the gttc library.
+//
+// The gttc library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gttc library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gttc library. If not, see <http://www.gnu.org/licenses/>.
+
+package alien
+
+import (
+	"math/big"
+
+	"github.com/TTCECO/gttc/common"
+	"github.com/TTCECO/gttc/consensus"
+	"github.com/TTCECO/gttc/core/types"
+	"github.com/TTCECO/gttc/rpc"
+)
+
+// API is a user facing RPC API to inspect the state of signers, candidates,
+// voters and punishments of the delegated-proof-of-stake scheme. It has no
+// methods that control signing or voting.
+type API struct {
+	chain consensus.ChainReader
+	alien *Alien
+}
+
+// GetSnapshot retrieves the state snapshot at a given block.
+func (api *API) GetSnapshot(number *rpc.BlockNumber) (*Snapshot, error) {
+	header := api.headerByNumber(number)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.alien.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+}
+
+// GetSnapshotAtHash retrieves the state snapshot at a given block hash.
+func (api *API) GetSnapshotAtHash(hash common.Hash) (*Snapshot, error) {
+	header := api.chain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.alien.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+}
+
+// GetSigners retrieves the list of authorized signers in the signer queue at
+// the specified block.
+func (api *API) GetSigners(number *rpc.BlockNumber) ([]common.Address, error) {
+	snap, err := api.GetSnapshot(number)
+	if err != nil {
+		return nil, err
+	}
+	return snap.signers(), nil
+}
+
+// GetSignersAtHash retrieves the list of authorized signers in the signer
+// queue at the specified block hash.
+func (api *API) GetSignersAtHash(hash common.Hash) ([]common.Address, error) {
+	snap, err := api.GetSnapshotAtHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	return snap.signers(), nil
+}
+
+// GetTally retrieves the per-candidate stake tally at the specified block.
+func (api *API) GetTally(number *rpc.BlockNumber) (map[common.Address]*big.Int, error) {
+	snap, err := api.GetSnapshot(number)
+	if err != nil {
+		return nil, err
+	}
+	return snap.Tally, nil
+}
+
+// GetVoters retrieves the voters and the block number they last voted at, at
+// the specified block.
+func (api *API) GetVoters(number *rpc.BlockNumber) (map[common.Address]*big.Int, error) {
+	snap, err := api.GetSnapshot(number)
+	if err != nil {
+		return nil, err
+	}
+	return snap.Voters, nil
+}
+
+// GetPunished retrieves the punish credit debt of every signer that has
+// missed a seal, at the specified block.
+func (api *API) GetPunished(number *rpc.BlockNumber) (map[common.Address]uint64, error) {
+	snap, err := api.GetSnapshot(number)
+	if err != nil {
+		return nil, err
+	}
+	return snap.Punished, nil
+}
+
+// GetSignerQueueAt computes the signer queue for the loop following the
+// specified block, as it would be recomputed from the snapshot tally.
+func (api *API) GetSignerQueueAt(number *rpc.BlockNumber) ([]common.Address, error) {
+	snap, err := api.GetSnapshot(number)
+	if err != nil {
+		return nil, err
+	}
+	return snap.getSignerQueue(), nil
+}
+
+// headerByNumber resolves a header from a (possibly nil, possibly "latest")
+// *rpc.BlockNumber.
+func (api *API) headerByNumber(number *rpc.BlockNumber) *types.Header {
+	if number == nil || *number == rpc.LatestBlockNumber {
+		return api.chain.CurrentHeader()
+	}
+	return api.chain.GetHeaderByNumber(uint64(number.Int64()))
+}
@@ -0,0 +1,33 @@
+// Copyright 2017 The gttc Authors
+// This file is part of the gttc library.
+//
+// The gttc library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gttc library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gttc library. If not, see <http://www.gnu.org/licenses/>.
+
+package alien
+
+import "errors"
+
+var (
+	// errUnknownBlock is returned when the list of signers is requested for a block
+	// that is not part of the local blockchain.
+	errUnknownBlock = errors.New("unknown block")
+
+	// errInvalidVotingChain is returned when the headers applied to a snapshot are
+	// not contiguous or do not start right after the snapshot's block.
+	errInvalidVotingChain = errors.New("invalid voting chain")
+
+	// errUnknownSnapshot is returned when the snapshot for a requested block number
+	// or hash cannot be found or reconstructed.
+	errUnknownSnapshot = errors.New("unknown snapshot")
+)
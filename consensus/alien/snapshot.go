@@ -19,6 +19,8 @@
 package alien
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"math/big"
 	"math/rand"
@@ -26,7 +28,9 @@ import (
 	"time"
 
 	"github.com/TTCECO/gttc/common"
+	"github.com/TTCECO/gttc/core/state"
 	"github.com/TTCECO/gttc/core/types"
+	"github.com/TTCECO/gttc/crypto"
 	"github.com/TTCECO/gttc/ethdb"
 	"github.com/TTCECO/gttc/params"
 	"github.com/TTCECO/gttc/rlp"
@@ -34,12 +38,13 @@ import (
 )
 
 const (
-	defaultFullCredit 	= 1000				// no punished
-	missingPublishCredit = 100				// punished for missing one block seal
-	signRewardCredit	= 10				// seal one block
-	minCalSignerQueueCredit = 300			// when calculate the signerQueue,
-											// the credit of one signer is at least minCalSignerQueueCredit
+	defaultFullCredit       = 1000 // no punished
+	missingPublishCredit    = 100  // punished for missing one block seal
+	signRewardCredit        = 10   // seal one block
+	minCalSignerQueueCredit = 300  // when calculate the signerQueue,
+	// the credit of one signer is at least minCalSignerQueueCredit
 )
+
 // Snapshot is the state of the authorization voting at a given point in time.
 type Snapshot struct {
 	config   *params.AlienConfig // Consensus engine parameters to fine tune behavior
@@ -50,31 +55,47 @@ type Snapshot struct {
 
 	Signers []*common.Address `json:"signers"` // Signers queue in current header
 	// The signer validate should judge by last snapshot
-	Votes  map[common.Address]*Vote    `json:"votes"`  // All validate votes from genesis block
-	Tally  map[common.Address]*big.Int `json:"tally"`  // Stake for each candidate address
-	Voters map[common.Address]*big.Int `json:"voters"` // block number for each voter address
-	Punished map[common.Address] uint64 `json:"punished"` // The signer be punished count cause of missing seal
-
-	HeaderTime    uint64 `json:"headerTime"`    // Time of the current header
-	LoopStartTime uint64 `json:"loopStartTime"` // Start Time of the current loop
-
+	Votes     map[common.Address]*Vote       `json:"votes"`     // All validate votes from genesis block
+	Tally     map[common.Address]*big.Int    `json:"tally"`     // Stake for each candidate address
+	Voters    map[common.Address]*big.Int    `json:"voters"`    // block number for each voter address
+	Punished  map[common.Address]uint64      `json:"punished"`  // The signer be punished count cause of missing seal
+	Jailed    map[common.Address]uint64      `json:"jailed"`    // Block number a signer was jailed at, once Punished crosses JailThreshold
+	Proposals map[common.Hash]*ProposalState `json:"proposals"` // In-progress jail/unjail governance proposals, keyed by proposal hash
+	VrfSeeds  map[common.Address][]byte      `json:"vrfSeeds"`  // Candidate VRF proofs over the previous loop's hash, reset every loop
+
+	Delegations    map[common.Address][]common.Address            `json:"delegations"`    // candidate -> delegators backing it
+	DelegateStakes map[common.Address]map[common.Address]*big.Int `json:"delegateStakes"` // delegator -> candidate -> its last recorded partial stake
+
+	MintedRewards      map[common.Address]*big.Int `json:"mintedRewards"`      // Total subsidy minted for blocks sealed by each coinbase so far, delegators' share included
+	AccumulatedRewards *big.Int                    `json:"accumulatedRewards"` // Total reward minted across every signer so far, for inflation accounting
+
+	HeaderTime    uint64      `json:"headerTime"`    // Time of the current header
+	LoopStartTime uint64      `json:"loopStartTime"` // Start Time of the current loop
+	LoopSeedHash  common.Hash `json:"loopSeedHash"`  // Hash fixed at the start of the current loop that CurrentBlockVrfProofs sign over
 }
 
 // newSnapshot creates a new snapshot with the specified startup parameters. only ever use if for
 // the genesis block.
 func newSnapshot(config *params.AlienConfig, sigcache *lru.ARCCache, hash common.Hash, votes []*Vote) *Snapshot {
 	snap := &Snapshot{
-		config:        config,
-		sigcache:      sigcache,
-		Number:        0,
-		Hash:          hash,
-		Signers:       []*common.Address{},
-		Votes:         make(map[common.Address]*Vote),
-		Tally:         make(map[common.Address]*big.Int),
-		Voters:        make(map[common.Address]*big.Int),
-		Punished:		make(map[common.Address]uint64),
-		HeaderTime:    uint64(time.Now().Unix()) - 1,//config.GenesisTimestamp - 1, //
-		LoopStartTime: config.GenesisTimestamp,
+		config:             config,
+		sigcache:           sigcache,
+		Number:             0,
+		Hash:               hash,
+		Signers:            []*common.Address{},
+		Votes:              make(map[common.Address]*Vote),
+		Tally:              make(map[common.Address]*big.Int),
+		Voters:             make(map[common.Address]*big.Int),
+		Punished:           make(map[common.Address]uint64),
+		Jailed:             make(map[common.Address]uint64),
+		Proposals:          make(map[common.Hash]*ProposalState),
+		VrfSeeds:           make(map[common.Address][]byte),
+		Delegations:        make(map[common.Address][]common.Address),
+		DelegateStakes:     make(map[common.Address]map[common.Address]*big.Int),
+		MintedRewards:      make(map[common.Address]*big.Int),
+		AccumulatedRewards: big.NewInt(0),
+		HeaderTime:         uint64(time.Now().Unix()) - 1, //config.GenesisTimestamp - 1, //
+		LoopStartTime:      config.GenesisTimestamp,
 	}
 
 	for _, vote := range votes {
@@ -94,7 +115,7 @@ func newSnapshot(config *params.AlienConfig, sigcache *lru.ARCCache, hash common
 	}
 
 	for i := 0; i < int(config.MaxSignerCount); i++ {
-		snap.Signers = append(snap.Signers, &config.SelfVoteSigners[i % len(config.SelfVoteSigners)])
+		snap.Signers = append(snap.Signers, &config.SelfVoteSigners[i%len(config.SelfVoteSigners)])
 	}
 
 	return snap
@@ -133,14 +154,22 @@ func (s *Snapshot) copy() *Snapshot {
 		Number:   s.Number,
 		Hash:     s.Hash,
 
-		Signers: make([]*common.Address, len(s.Signers)),
-		Votes:   make(map[common.Address]*Vote),
-		Tally:   make(map[common.Address]*big.Int),
-		Voters:  make(map[common.Address]*big.Int),
-		Punished:make(map[common.Address]uint64),
+		Signers:            make([]*common.Address, len(s.Signers)),
+		Votes:              make(map[common.Address]*Vote),
+		Tally:              make(map[common.Address]*big.Int),
+		Voters:             make(map[common.Address]*big.Int),
+		Punished:           make(map[common.Address]uint64),
+		Jailed:             make(map[common.Address]uint64),
+		Proposals:          make(map[common.Hash]*ProposalState),
+		VrfSeeds:           make(map[common.Address][]byte),
+		Delegations:        make(map[common.Address][]common.Address),
+		DelegateStakes:     make(map[common.Address]map[common.Address]*big.Int),
+		MintedRewards:      make(map[common.Address]*big.Int),
+		AccumulatedRewards: new(big.Int).Set(s.AccumulatedRewards),
 
 		HeaderTime:    s.HeaderTime,
 		LoopStartTime: s.LoopStartTime,
+		LoopSeedHash:  s.LoopSeedHash,
 	}
 	copy(cpy.Signers, s.Signers)
 	for voter, vote := range s.Votes {
@@ -156,9 +185,34 @@ func (s *Snapshot) copy() *Snapshot {
 	for voter, number := range s.Voters {
 		cpy.Voters[voter] = number
 	}
-	for signer, cnt := range s.Punished{
+	for signer, cnt := range s.Punished {
 		cpy.Punished[signer] = cnt
 	}
+	for signer, jailedAt := range s.Jailed {
+		cpy.Jailed[signer] = jailedAt
+	}
+	for hash, proposal := range s.Proposals {
+		votes := make(map[common.Address]*big.Int)
+		for voter, stake := range proposal.Votes {
+			votes[voter] = new(big.Int).Set(stake)
+		}
+		cpy.Proposals[hash] = &ProposalState{Type: proposal.Type, Target: proposal.Target, Votes: votes}
+	}
+	for signer, proof := range s.VrfSeeds {
+		cpy.VrfSeeds[signer] = proof
+	}
+	for candidate, delegators := range s.Delegations {
+		cpy.Delegations[candidate] = append([]common.Address{}, delegators...)
+	}
+	for delegator, stakes := range s.DelegateStakes {
+		cpy.DelegateStakes[delegator] = make(map[common.Address]*big.Int)
+		for candidate, stake := range stakes {
+			cpy.DelegateStakes[delegator][candidate] = new(big.Int).Set(stake)
+		}
+	}
+	for signer, minted := range s.MintedRewards {
+		cpy.MintedRewards[signer] = new(big.Int).Set(minted)
+	}
 	return cpy
 }
 
@@ -191,6 +245,14 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 		headerExtra := HeaderExtra{}
 		rlp.DecodeBytes(header.Extra[extraVanity:len(header.Extra)-extraSeal], &headerExtra)
 		snap.HeaderTime = header.Time.Uint64()
+		if headerExtra.LoopStartTime != snap.LoopStartTime {
+			// a new loop started, the previous loop's VRF proofs no longer apply;
+			// fix the seed this loop's proofs must sign over to the hash that
+			// closed the previous loop, which every candidate already knows
+			// before this header is built
+			snap.VrfSeeds = make(map[common.Address][]byte)
+			snap.LoopSeedHash = header.ParentHash
+		}
 		snap.LoopStartTime = headerExtra.LoopStartTime
 		snap.Signers = nil
 		for i := range headerExtra.SignerQueue {
@@ -222,12 +284,30 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 				// do not modify header number of snap.Voters
 			}
 		}
+		// deal delegator stake splits, letting one account back several candidates
+		for _, delegation := range headerExtra.CurrentBlockDelegations {
+			stakes, ok := snap.DelegateStakes[delegation.Delegator]
+			if !ok {
+				stakes = make(map[common.Address]*big.Int)
+				snap.DelegateStakes[delegation.Delegator] = stakes
+			}
+			if oldStake, ok := stakes[delegation.Candidate]; ok {
+				snap.Tally[delegation.Candidate].Sub(snap.Tally[delegation.Candidate], oldStake)
+			} else {
+				snap.Delegations[delegation.Candidate] = append(snap.Delegations[delegation.Candidate], delegation.Delegator)
+			}
+			if _, ok := snap.Tally[delegation.Candidate]; !ok {
+				snap.Tally[delegation.Candidate] = big.NewInt(0)
+			}
+			snap.Tally[delegation.Candidate].Add(snap.Tally[delegation.Candidate], delegation.PartialStake)
+			stakes[delegation.Candidate] = delegation.PartialStake
+		}
 		// set punished count to half of origin in Epoch
-		if header.Number.Uint64() % snap.config.Epoch == 0 {
-			for bePublished := range snap.Punished{
-				if count := snap.Punished[bePublished] / 2; count > 0{
+		if header.Number.Uint64()%snap.config.Epoch == 0 {
+			for bePublished := range snap.Punished {
+				if count := snap.Punished[bePublished] / 2; count > 0 {
 					snap.Punished[bePublished] = count
-				}else {
+				} else {
 					delete(snap.Punished, bePublished)
 				}
 			}
@@ -236,17 +316,88 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 		for _, signerMissing := range headerExtra.SignerMissing {
 			if _, ok := snap.Punished[signerMissing]; ok {
 				snap.Punished[signerMissing] += missingPublishCredit
-			}else{
+			} else {
 				snap.Punished[signerMissing] = missingPublishCredit
 			}
 		}
 		// reduce the punish of sign signer
 		if _, ok := snap.Punished[header.Coinbase]; ok {
-			snap.Punished[header.Coinbase] -= signRewardCredit
-			if snap.Punished[header.Coinbase] <= 0 {
+			// Punished is unsigned, so subtracting past zero wraps around to a
+			// huge value instead of going negative; delete instead of
+			// underflowing once the debt would drop to or below zero
+			if snap.Punished[header.Coinbase] <= signRewardCredit {
 				delete(snap.Punished, header.Coinbase)
+			} else {
+				snap.Punished[header.Coinbase] -= signRewardCredit
+			}
+		}
+		// evict signers whose punish credit debt crossed the jail threshold
+		for signerMissing := range snap.Punished {
+			if snap.config.JailThreshold > 0 && snap.Punished[signerMissing] > snap.config.JailThreshold {
+				snap.jail(signerMissing, header.Number.Uint64())
+			}
+		}
+		// re-include signers that served their jail period
+		for jailedSigner, jailedAt := range snap.Jailed {
+			if snap.config.JailPeriod > 0 && header.Number.Uint64()-jailedAt > snap.config.JailPeriod {
+				snap.unjail(jailedSigner)
 			}
 		}
+		// governance votes to jail/unjail a signer, overriding the automatic decision
+		for _, proposal := range headerExtra.CurrentBlockProposals {
+			state, ok := snap.Proposals[proposal.Hash]
+			if !ok {
+				state = &ProposalState{Type: proposal.Type, Target: proposal.Target, Votes: make(map[common.Address]*big.Int)}
+				snap.Proposals[proposal.Hash] = state
+			}
+			// clamp the proposal vote to the proposer's real voting power, so a
+			// proposer can't inflate a jail/unjail proposal's backing beyond
+			// what its own votes and delegations actually carry in Tally
+			stake := proposal.Stake
+			if max := snap.voterStake(proposal.Proposer); stake.Cmp(max) > 0 {
+				stake = max
+			}
+			state.Votes[proposal.Proposer] = stake
+
+			backing := big.NewInt(0)
+			for _, stake := range state.Votes {
+				backing.Add(backing, stake)
+			}
+			total := big.NewInt(0)
+			for _, stake := range snap.Tally {
+				total.Add(total, stake)
+			}
+			// a proposal passes once it is backed by a stake majority of the current tally
+			if total.Cmp(big.NewInt(0)) > 0 && backing.Mul(backing, big.NewInt(2)).Cmp(total) > 0 {
+				switch state.Type {
+				case ProposalTypeJail:
+					snap.jail(state.Target, header.Number.Uint64())
+				case ProposalTypeUnjail:
+					snap.unjail(state.Target)
+				}
+				delete(snap.Proposals, proposal.Hash)
+			}
+		}
+		// collect VRF proofs for the signer queue of the next loop, discarding
+		// any proof whose signature does not recover to the address it
+		// claims to be from
+		if snap.config.VRFEnabled {
+			for _, proof := range headerExtra.CurrentBlockVrfProofs {
+				pubkey, err := crypto.SigToPub(snap.LoopSeedHash.Bytes(), proof.Proof)
+				if err != nil || crypto.PubkeyToAddress(*pubkey) != proof.Signer {
+					continue
+				}
+				snap.VrfSeeds[proof.Signer] = proof.Proof
+			}
+		}
+		// record the subsidy minted for this block under its coinbase for inflation accounting
+		if headerExtra.CoinbaseReward != nil && headerExtra.CoinbaseReward.Cmp(big.NewInt(0)) > 0 {
+			if _, ok := snap.MintedRewards[header.Coinbase]; !ok {
+				snap.MintedRewards[header.Coinbase] = big.NewInt(0)
+			}
+			snap.MintedRewards[header.Coinbase].Add(snap.MintedRewards[header.Coinbase], headerExtra.CoinbaseReward)
+			snap.AccumulatedRewards.Add(snap.AccumulatedRewards, headerExtra.CoinbaseReward)
+		}
 	}
 	snap.Number += uint64(len(headers))
 	snap.Hash = headers[len(headers)-1].Hash()
@@ -278,6 +429,138 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 	return snap, nil
 }
 
+// jail evicts a signer from the signer queue as of blockNumber, burning its
+// self-vote stake out of Tally so it can no longer influence the queue.
+func (s *Snapshot) jail(signer common.Address, blockNumber uint64) {
+	if _, ok := s.Jailed[signer]; ok {
+		return
+	}
+	s.Jailed[signer] = blockNumber
+
+	// burn only the signer's own self-vote stake; other voters'/delegators'
+	// stake still backing this candidate must survive, since later votes,
+	// delegations and expired-vote cleanup keep adjusting Tally[signer] and
+	// would panic subtracting from a deleted map entry
+	selfStake := s.delegatorStake(signer, signer)
+	if selfStake == nil {
+		return
+	}
+	if tally, ok := s.Tally[signer]; ok {
+		tally.Sub(tally, selfStake)
+		if tally.Sign() <= 0 {
+			delete(s.Tally, signer)
+		}
+	}
+}
+
+// unjail re-admits a previously jailed signer at reduced credit, leaving it
+// to requalify for the signer queue on its own stake and seal record.
+func (s *Snapshot) unjail(signer common.Address) {
+	if _, ok := s.Jailed[signer]; !ok {
+		return
+	}
+	delete(s.Jailed, signer)
+	s.Punished[signer] = minCalSignerQueueCredit
+}
+
+// refreshTally re-reads every voter's and delegator's current balance from
+// state and rewrites Tally so stake follows live balance changes, instead of
+// only moving when an explicit ModifyPredecessorVotes entry is included for
+// a transfer. It is invoked by Alien.Finalize once the block's state has
+// been executed, on a copy of the parent block's snapshot.
+func (s *Snapshot) refreshTally(state *state.StateDB) {
+	s.Tally = make(map[common.Address]*big.Int)
+
+	for _, vote := range s.Votes {
+		if _, ok := s.Tally[vote.Candidate]; !ok {
+			s.Tally[vote.Candidate] = big.NewInt(0)
+		}
+		s.Tally[vote.Candidate].Add(s.Tally[vote.Candidate], state.GetBalance(vote.Voter))
+	}
+
+	// cap each delegator's total split stake at its balance, scaling every
+	// candidate's share down proportionally if the recorded partial stakes
+	// add up to more than the delegator actually holds
+	for delegator, stakes := range s.DelegateStakes {
+		recorded := big.NewInt(0)
+		for _, stake := range stakes {
+			recorded.Add(recorded, stake)
+		}
+		if recorded.Sign() <= 0 {
+			continue
+		}
+		balance := state.GetBalance(delegator)
+		for candidate, stake := range stakes {
+			contribution := stake
+			if recorded.Cmp(balance) > 0 {
+				contribution = new(big.Int).Mul(stake, balance)
+				contribution.Div(contribution, recorded)
+			}
+			if _, ok := s.Tally[candidate]; !ok {
+				s.Tally[candidate] = big.NewInt(0)
+			}
+			s.Tally[candidate].Add(s.Tally[candidate], contribution)
+		}
+	}
+}
+
+// signerRewardRatio is the percentage of a block's subsidy kept by the
+// signer that sealed it, the remainder being split pro-rata among the
+// delegators backing it.
+const signerRewardRatio = 20
+
+// delegatorStake returns the stake a delegator has placed behind candidate,
+// whether cast as a direct Vote or as a partial Delegation, or nil if the
+// delegator is not currently backing candidate.
+func (s *Snapshot) delegatorStake(candidate, delegator common.Address) *big.Int {
+	var total *big.Int
+	if vote, ok := s.Votes[delegator]; ok && vote.Candidate == candidate {
+		total = new(big.Int).Set(vote.Stake)
+	}
+	if stake, ok := s.DelegateStakes[delegator][candidate]; ok {
+		if total == nil {
+			total = big.NewInt(0)
+		}
+		total.Add(total, stake)
+	}
+	return total
+}
+
+// voterStake returns the total stake voter currently has backing any
+// candidate, whether cast as a direct Vote or split across Delegations,
+// which bounds the weight it may carry in a governance Proposal.
+func (s *Snapshot) voterStake(voter common.Address) *big.Int {
+	total := big.NewInt(0)
+	if vote, ok := s.Votes[voter]; ok {
+		total.Add(total, vote.Stake)
+	}
+	for _, stake := range s.DelegateStakes[voter] {
+		total.Add(total, stake)
+	}
+	return total
+}
+
+// rewardFor splits a block's subsidy between the signer that sealed it and
+// one of the delegators backing it, proportional to that delegator's stake
+// within Tally[signer]. Pass delegator == signer to get the signer's own
+// cut.
+func (s *Snapshot) rewardFor(signer, delegator common.Address, subsidy *big.Int) *big.Int {
+	if subsidy == nil || subsidy.Sign() <= 0 {
+		return big.NewInt(0)
+	}
+	if delegator == signer {
+		return new(big.Int).Div(new(big.Int).Mul(subsidy, big.NewInt(signerRewardRatio)), big.NewInt(100))
+	}
+	stake := s.delegatorStake(signer, delegator)
+	tally, ok := s.Tally[signer]
+	if stake == nil || !ok || tally.Sign() <= 0 {
+		return big.NewInt(0)
+	}
+	votersCut := new(big.Int).Div(new(big.Int).Mul(subsidy, big.NewInt(100-signerRewardRatio)), big.NewInt(100))
+	reward := new(big.Int).Mul(votersCut, stake)
+	return reward.Div(reward, tally)
+}
+
 // inturn returns if a signer at a given block height is in-turn or not.
 func (s *Snapshot) inturn(signer common.Address, headerTime uint64) bool {
 
@@ -292,15 +575,22 @@ func (s *Snapshot) inturn(signer common.Address, headerTime uint64) bool {
 	return true
 }
 
-type TallyItem struct{
-	addr common.Address
+type TallyItem struct {
+	addr  common.Address
 	stake *big.Int
 }
 type TallySlice []TallyItem
 
-func (s TallySlice) Len() int           { return len(s) }
-func (s TallySlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
-func (s TallySlice) Less(i, j int) bool { return s[i].stake.Cmp(s[j].stake) > 0 }
+func (s TallySlice) Len() int      { return len(s) }
+func (s TallySlice) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s TallySlice) Less(i, j int) bool {
+	if cmp := s[i].stake.Cmp(s[j].stake); cmp != 0 {
+		return cmp > 0
+	}
+	// tie-break on address so the ordering does not depend on Tally's map
+	// iteration order, which Go deliberately randomizes
+	return bytes.Compare(s[i].addr[:], s[j].addr[:]) < 0
+}
 
 // get signer queue when one loop finished
 func (s *Snapshot) getSignerQueue() []common.Address {
@@ -309,32 +599,92 @@ func (s *Snapshot) getSignerQueue() []common.Address {
 	var topStakeAddress []common.Address
 
 	for address, stake := range s.Tally {
-		if _,ok := s.Punished[address]; ok{
-			creditWeight := defaultFullCredit - s.Punished[address]
-			if creditWeight < minCalSignerQueueCredit { creditWeight = minCalSignerQueueCredit }
+		if _, ok := s.Jailed[address]; ok {
+			continue
+		}
+		if _, ok := s.Punished[address]; ok {
+			// Punished is unsigned and may exceed defaultFullCredit, so clamp
+			// before subtracting instead of letting the difference wrap around
+			creditWeight := uint64(minCalSignerQueueCredit)
+			if punished := s.Punished[address]; punished < defaultFullCredit-minCalSignerQueueCredit {
+				creditWeight = defaultFullCredit - punished
+			}
 			tallySlice = append(tallySlice, TallyItem{address, new(big.Int).Mul(stake, big.NewInt(int64(creditWeight)))})
-		}else{
+		} else {
 			tallySlice = append(tallySlice, TallyItem{address, new(big.Int).Mul(stake, big.NewInt(defaultFullCredit))})
 		}
 	}
 
 	sort.Sort(TallySlice(tallySlice))
 	queueLength := int(s.config.MaxSignerCount)
-	if queueLength > len(tallySlice){
+	if queueLength > len(tallySlice) {
 		queueLength = len(tallySlice)
 	}
 
 	for _, tallyItem := range tallySlice[:queueLength] {
-			topStakeAddress = append(topStakeAddress, tallyItem.addr)
+		topStakeAddress = append(topStakeAddress, tallyItem.addr)
 	}
-	// Set the top candidates in random order
-	for i := 0; i < len(topStakeAddress); i++ {
-		newPos := rand.Int() % len(topStakeAddress)
-		topStakeAddress[i], topStakeAddress[newPos] = topStakeAddress[newPos], topStakeAddress[i]
+
+	if s.config.VRFEnabled && s.allHaveVrfSeed(topStakeAddress) {
+		s.sortByVrfSeed(topStakeAddress)
+		return topStakeAddress
+	}
+
+	// Set the top candidates in a deterministic order: every node applying
+	// the same headers arrives at the same snapshot (Hash, Number,
+	// LoopStartTime), so seeding math/rand from it reproduces the same
+	// Fisher-Yates shuffle everywhere, instead of diverging on the global,
+	// unseeded math/rand source.
+	shuffle := rand.New(rand.NewSource(s.signerQueueSeed()))
+	for i := len(topStakeAddress) - 1; i > 0; i-- {
+		j := shuffle.Intn(i + 1)
+		topStakeAddress[i], topStakeAddress[j] = topStakeAddress[j], topStakeAddress[i]
 	}
 	return topStakeAddress
 }
 
+// signerQueueSeed derives a shuffle seed every node can reproduce from the
+// snapshot it already agrees on.
+func (s *Snapshot) signerQueueSeed() int64 {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf[:8], s.LoopStartTime)
+	binary.BigEndian.PutUint64(buf[8:], s.Number)
+	seed := crypto.Keccak256(s.Hash.Bytes(), buf)
+	return int64(binary.BigEndian.Uint64(seed[:8]))
+}
+
+// allHaveVrfSeed reports whether every address has submitted a VRF proof for
+// the current loop.
+func (s *Snapshot) allHaveVrfSeed(addresses []common.Address) bool {
+	for _, address := range addresses {
+		if _, ok := s.VrfSeeds[address]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// sortByVrfSeed orders addresses by the keccak256 hash of their VRF proof,
+// which is bias-resistant against a top staker grinding their own stake to
+// influence the shuffle.
+func (s *Snapshot) sortByVrfSeed(addresses []common.Address) {
+	sort.Slice(addresses, func(i, j int) bool {
+		hi := crypto.Keccak256(s.VrfSeeds[addresses[i]])
+		hj := crypto.Keccak256(s.VrfSeeds[addresses[j]])
+		return bytes.Compare(hi, hj) < 0
+	})
+}
+
+// signers returns the signer queue of the snapshot as a plain slice of
+// addresses, suitable for JSON-RPC responses.
+func (s *Snapshot) signers() []common.Address {
+	signers := make([]common.Address, len(s.Signers))
+	for i, signer := range s.Signers {
+		signers[i] = *signer
+	}
+	return signers
+}
+
 // check if address belong to voter
 func (s *Snapshot) isVoter(address common.Address) bool {
 	if _, ok := s.Voters[address]; ok {
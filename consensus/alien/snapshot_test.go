@@ -0,0 +1,222 @@
+// Copyright 2017 The gttc Authors
+// This file is part of the gttc library.
+//
+// The gttc library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gttc library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gttc library. If not, see <http://www.gnu.org/licenses/>.
+
+package alien
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/TTCECO/gttc/common"
+	"github.com/TTCECO/gttc/core/state"
+	"github.com/TTCECO/gttc/ethdb"
+	"github.com/TTCECO/gttc/params"
+)
+
+// newTestStateDB returns an empty in-memory state database for tests that
+// need to exercise balance-driven logic such as refreshTally.
+func newTestStateDB(t *testing.T) *state.StateDB {
+	db, err := ethdb.NewMemDatabase()
+	if err != nil {
+		t.Fatalf("failed to create memory database: %v", err)
+	}
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to create state database: %v", err)
+	}
+	return statedb
+}
+
+func newTestSnapshot() *Snapshot {
+	config := &params.AlienConfig{MaxSignerCount: 3}
+	snap := &Snapshot{
+		config:        config,
+		Number:        128,
+		Hash:          common.HexToHash("0x1234"),
+		Tally:         make(map[common.Address]*big.Int),
+		Punished:      make(map[common.Address]uint64),
+		Jailed:        make(map[common.Address]uint64),
+		VrfSeeds:      make(map[common.Address][]byte),
+		LoopStartTime: 1000,
+	}
+	for i := byte(1); i <= 5; i++ {
+		snap.Tally[common.BytesToAddress([]byte{i})] = big.NewInt(int64(i) * 100)
+	}
+	return snap
+}
+
+// TestGetSignerQueueDeterministic asserts that two snapshots which agree on
+// Hash, Number, LoopStartTime and Tally always compute the same signer queue
+// order, which is required for every node to reach the same consensus state.
+func TestGetSignerQueueDeterministic(t *testing.T) {
+	a := newTestSnapshot().getSignerQueue()
+	b := newTestSnapshot().getSignerQueue()
+
+	if len(a) != len(b) {
+		t.Fatalf("queue length mismatch: %d vs %d", len(a), len(b))
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("queue order diverged at index %d: %x vs %x", i, a[i], b[i])
+		}
+	}
+}
+
+// TestSignerQueueSeedChangesWithSnapshot asserts the seed actually depends on
+// the snapshot, so the shuffle is not a constant no matter what is hashed.
+func TestSignerQueueSeedChangesWithSnapshot(t *testing.T) {
+	base := newTestSnapshot()
+	changed := newTestSnapshot()
+	changed.Number = base.Number + 1
+
+	if base.signerQueueSeed() == changed.signerQueueSeed() {
+		t.Fatalf("expected signerQueueSeed to depend on snapshot Number")
+	}
+}
+
+// TestJailBurnsOnlySelfStake asserts that jailing a signer only removes its
+// own self-vote stake from Tally, leaving the stake other voters and
+// delegators placed behind it intact.
+func TestJailBurnsOnlySelfStake(t *testing.T) {
+	signer := common.BytesToAddress([]byte{1})
+	backer := common.BytesToAddress([]byte{2})
+
+	snap := &Snapshot{
+		Tally:  map[common.Address]*big.Int{signer: big.NewInt(80)},
+		Jailed: make(map[common.Address]uint64),
+		Votes: map[common.Address]*Vote{
+			signer: {Voter: signer, Candidate: signer, Stake: big.NewInt(50)},
+		},
+		DelegateStakes: map[common.Address]map[common.Address]*big.Int{
+			backer: {signer: big.NewInt(30)},
+		},
+	}
+
+	snap.jail(signer, 10)
+
+	if _, ok := snap.Jailed[signer]; !ok {
+		t.Fatalf("expected signer to be jailed")
+	}
+	tally, ok := snap.Tally[signer]
+	if !ok {
+		t.Fatalf("expected backer's stake to survive jailing, but Tally entry was deleted")
+	}
+	if tally.Cmp(big.NewInt(30)) != 0 {
+		t.Fatalf("expected remaining tally of 30, got %s", tally)
+	}
+}
+
+// TestJailDeletesTallyOnceFullyUnbacked asserts that jailing a signer with no
+// other backers removes its now-empty Tally entry instead of leaving a
+// zero-value one behind.
+func TestJailDeletesTallyOnceFullyUnbacked(t *testing.T) {
+	signer := common.BytesToAddress([]byte{1})
+	snap := &Snapshot{
+		Tally:  map[common.Address]*big.Int{signer: big.NewInt(50)},
+		Jailed: make(map[common.Address]uint64),
+		Votes: map[common.Address]*Vote{
+			signer: {Voter: signer, Candidate: signer, Stake: big.NewInt(50)},
+		},
+	}
+
+	snap.jail(signer, 10)
+
+	if _, ok := snap.Tally[signer]; ok {
+		t.Fatalf("expected Tally entry to be removed once no backers remain")
+	}
+}
+
+// TestVoterStakeSumsVotesAndDelegations asserts that voterStake accounts for
+// both a voter's direct Vote and every partial Delegation it has made, since
+// that combined figure bounds how much weight it may carry in a Proposal.
+func TestVoterStakeSumsVotesAndDelegations(t *testing.T) {
+	voter := common.BytesToAddress([]byte{1})
+	candA := common.BytesToAddress([]byte{2})
+
+	snap := &Snapshot{
+		Votes: map[common.Address]*Vote{
+			voter: {Voter: voter, Candidate: candA, Stake: big.NewInt(40)},
+		},
+		DelegateStakes: map[common.Address]map[common.Address]*big.Int{
+			voter: {
+				common.BytesToAddress([]byte{3}): big.NewInt(20),
+				common.BytesToAddress([]byte{4}): big.NewInt(10),
+			},
+		},
+	}
+
+	if got := snap.voterStake(voter); got.Cmp(big.NewInt(70)) != 0 {
+		t.Fatalf("expected combined voter stake of 70, got %s", got)
+	}
+}
+
+// TestRefreshTallyCapsDelegatorAcrossCandidates asserts that a delegator who
+// has split more stake across candidates than its live balance covers has
+// every candidate's share scaled down proportionally, rather than each
+// candidate independently being capped against the full balance.
+func TestRefreshTallyCapsDelegatorAcrossCandidates(t *testing.T) {
+	delegator := common.BytesToAddress([]byte{1})
+	candA := common.BytesToAddress([]byte{2})
+	candB := common.BytesToAddress([]byte{3})
+
+	statedb := newTestStateDB(t)
+	statedb.AddBalance(delegator, big.NewInt(100))
+
+	snap := &Snapshot{
+		Votes: make(map[common.Address]*Vote),
+		DelegateStakes: map[common.Address]map[common.Address]*big.Int{
+			delegator: {
+				candA: big.NewInt(80),
+				candB: big.NewInt(80),
+			},
+		},
+	}
+
+	snap.refreshTally(statedb)
+
+	if got := snap.Tally[candA]; got.Cmp(big.NewInt(50)) != 0 {
+		t.Fatalf("expected candA tally of 50, got %s", got)
+	}
+	if got := snap.Tally[candB]; got.Cmp(big.NewInt(50)) != 0 {
+		t.Fatalf("expected candB tally of 50, got %s", got)
+	}
+}
+
+// TestRewardForSplitsBetweenSignerAndDelegator asserts that a block's
+// subsidy is split signerRewardRatio/100 to the sealing signer and the rest
+// pro-rata among delegators by their share of the signer's tally.
+func TestRewardForSplitsBetweenSignerAndDelegator(t *testing.T) {
+	signer := common.BytesToAddress([]byte{1})
+	delegator := common.BytesToAddress([]byte{2})
+
+	snap := &Snapshot{
+		Tally: map[common.Address]*big.Int{signer: big.NewInt(100)},
+		DelegateStakes: map[common.Address]map[common.Address]*big.Int{
+			delegator: {signer: big.NewInt(25)},
+		},
+	}
+	subsidy := big.NewInt(100)
+
+	signerReward := snap.rewardFor(signer, signer, subsidy)
+	if signerReward.Cmp(big.NewInt(20)) != 0 {
+		t.Fatalf("expected signer reward of 20, got %s", signerReward)
+	}
+
+	delegatorReward := snap.rewardFor(signer, delegator, subsidy)
+	if delegatorReward.Cmp(big.NewInt(20)) != 0 {
+		t.Fatalf("expected delegator reward of 20 (25%% of the 80 voters' cut), got %s", delegatorReward)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2017 The gttc Authors
+// This file is part of the gttc library.
+//
+// The gttc library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gttc library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gttc library. If not, see <http://www.gnu.org/licenses/>.
+
+package alien
+
+import (
+	"math/big"
+
+	"github.com/TTCECO/gttc/common"
+)
+
+// Vote represents a single vote cast by a voter for a candidate signer, carrying
+// the stake backing the vote at the time it was cast.
+type Vote struct {
+	Voter     common.Address `json:"voter"`
+	Candidate common.Address `json:"candidate"`
+	Stake     *big.Int       `json:"stake"`
+}
+
+// HeaderExtra is the structure of extra data in the block header, it is encoded
+// with RLP into the Extra field between extraVanity and extraSeal.
+type HeaderExtra struct {
+	LoopStartTime           uint64
+	SignerQueue             []common.Address
+	SignerMissing           []common.Address
+	CurrentBlockVotes       []Vote
+	ModifyPredecessorVotes  []Vote
+	CurrentBlockProposals   []Proposal
+	CurrentBlockVrfProofs   []VrfProof
+	CurrentBlockDelegations []Delegation
+	CoinbaseReward          *big.Int // whole subsidy minted for this block, split between its coinbase and delegators, set by Alien.Finalize
+}
+
+// Delegation represents a single delegator's partial stake placed behind a
+// candidate. Unlike a direct Vote, which replaces a voter's previous choice
+// outright, a delegator may hold a Delegation with several candidates at
+// once, so long as the sum of PartialStake across them does not exceed its
+// balance.
+type Delegation struct {
+	Delegator    common.Address `json:"delegator"`
+	Candidate    common.Address `json:"candidate"`
+	PartialStake *big.Int       `json:"partialStake"`
+}
+
+// VrfProof is a candidate signer's proof over the hash that closed the
+// previous loop (Snapshot.LoopSeedHash), submitted while config.VRFEnabled
+// is set. Snapshot.apply discards any proof that does not recover to
+// Signer. When every candidate picked for the signer queue has a valid
+// proof, Snapshot.getSignerQueue sorts them by the proof hash instead of
+// shuffling, so the queue order is bias-resistant against a malicious top
+// staker grinding their own stake; otherwise it falls back to the seeded
+// shuffle.
+type VrfProof struct {
+	Signer common.Address `json:"signer"`
+	Proof  []byte         `json:"proof"` // signature of Signer over Snapshot.LoopSeedHash
+}
+
+// Proposal types understood by Snapshot.apply, cast by a voter with stake
+// in Tally to jail or unjail a signer, overriding the automatic decision
+// that would otherwise follow from the Punished credit debt alone.
+const (
+	ProposalTypeJail   = 1
+	ProposalTypeUnjail = 2
+)
+
+// Proposal is a single governance vote cast by a voter against a target
+// signer, proposing to jail or unjail it. Proposals with the same Hash
+// accumulate stake across headers in Snapshot.Proposals until the backing
+// stake crosses the pass threshold, at which point the action is executed.
+type Proposal struct {
+	Hash     common.Hash    `json:"hash"`     // identifies a single jail/unjail round against Target
+	Type     uint64         `json:"type"`     // ProposalTypeJail or ProposalTypeUnjail
+	Target   common.Address `json:"target"`   // signer the proposal concerns
+	Proposer common.Address `json:"proposer"` // voter casting this proposal vote
+	Stake    *big.Int       `json:"stake"`    // stake backing this proposal vote
+}
+
+// ProposalState tracks the in-progress stake backing a jail/unjail proposal
+// identified by its Hash, until it passes or the candidacy is resolved.
+type ProposalState struct {
+	Type   uint64                      `json:"type"`
+	Target common.Address              `json:"target"`
+	Votes  map[common.Address]*big.Int `json:"votes"`
+}
@@ -0,0 +1,40 @@
+// Copyright 2017 The gttc Authors
+// This file is part of the gttc library.
+//
+// The gttc library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The gttc library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the gttc library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"math/big"
+
+	"github.com/TTCECO/gttc/common"
+)
+
+// AlienConfig is the consensus engine configs for delegated-proof-of-stake based sealing.
+type AlienConfig struct {
+	Period           uint64           `json:"period"`           // Number of seconds between blocks to enforce
+	Epoch            uint64           `json:"epoch"`            // Number of blocks after which a vote expires and between halvings of punished counts
+	MaxSignerCount   uint64           `json:"maxSignersCount"`  // Max count of signers in the signer queue
+	GenesisTimestamp uint64           `json:"genesisTimestamp"` // Timestamp of the genesis block, loop start time is derived from it
+	SelfVoteSigners  []common.Address `json:"selfVoteSigners"`  // Signers that self vote at genesis, used to fill the initial signer queue
+
+	JailThreshold uint64 `json:"jailThreshold"` // Punished credit debt above which a signer is evicted to Jailed
+	JailPeriod    uint64 `json:"jailPeriod"`    // Number of blocks a signer stays in Jailed before it may be re-included
+
+	VRFEnabled bool `json:"vrfEnabled"` // Order the signer queue by per-candidate VRF proof instead of a seeded shuffle, once every queued candidate has one
+
+	BlockReward         *big.Int `json:"blockReward"`         // Subsidy minted to the coinbase (and its delegators) for each sealed block
+	RewardHalvingPeriod uint64   `json:"rewardHalvingPeriod"` // Number of blocks between each halving of BlockReward, 0 disables halving
+}